@@ -0,0 +1,41 @@
+// Package generated holds the state devspace persists between runs
+// (generated.yaml), as opposed to the user-authored config in
+// pkg/devspace/config/versions/latest.
+package generated
+
+import "time"
+
+// CacheConfig is the per-context/namespace state devspace persists between
+// runs
+type CacheConfig struct {
+	// DeploymentRevisions holds the revision history of every deployment
+	// (keyed by DeploymentConfig.Name) that Rollback can revert to, trimmed
+	// to RevisionHistoryLimit by deploy.recordRevision
+	DeploymentRevisions map[string][]DeploymentRevision
+}
+
+// DeploymentRevision records the state of a single successful deploy, so
+// Rollback can revert to it later
+type DeploymentRevision struct {
+	// Revision numbers this deployment's revisions for a given Target,
+	// starting at 1
+	Revision int
+	// Target is the TargetRef this revision was deployed to, or empty for a
+	// deployment with no Targets configured
+	Target string
+	// Backend is the deployer method used ("kubectl", "helm", "kustomize",
+	// "manifests", or a custom backend name)
+	Backend     string
+	BuiltImages map[string]string
+	// ConfigHash is a hash of the DeploymentConfig used for this revision,
+	// so future tooling can detect config drift between revisions
+	ConfigHash string
+	Timestamp  time.Time
+
+	// Manifests holds the exact manifests applied for this revision, for
+	// every backend except helm (see deploy.ManifestReporter)
+	Manifests []byte
+	// HelmRelease is the Helm release name to roll back when Backend is
+	// "helm"; Helm tracks its own revision history internally
+	HelmRelease string
+}
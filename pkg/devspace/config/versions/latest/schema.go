@@ -0,0 +1,129 @@
+// Package latest defines the most recent version of the devspace.yaml config
+// schema.
+package latest
+
+import "time"
+
+// Config is the root of a parsed devspace.yaml
+type Config struct {
+	Deployments []*DeploymentConfig
+}
+
+// DeploymentConfig configures a single thing devspace deploys. Exactly one
+// of Kubectl, Helm or Backend must be set to select the deployer.Interface
+// implementation used to deploy it (see pkg/devspace/deploy's
+// DeployerFactory registry).
+type DeploymentConfig struct {
+	Name      string
+	Namespace string
+
+	Kubectl *KubectlConfig
+	Helm    *HelmConfig
+
+	// Backend selects a deployer.Interface registered in pkg/devspace/deploy's
+	// DeployerFactory registry (e.g. "kustomize", "manifests") instead of the
+	// built-in kubectl/helm deployers.
+	Backend   string
+	Kustomize *KustomizeConfig
+	Manifests *ManifestsConfig
+
+	// Timeout caps how long Deploy waits for this deployment to become ready
+	// before giving up; zero falls back to the run-wide deploy Options.Timeout,
+	// and readiness.Wait waits 5 minutes when that is zero as well.
+	Timeout time.Duration
+	// Wait overrides whether Deploy waits for this deployment to become
+	// ready at all; nil falls back to the run-wide deploy Options.Wait.
+	Wait *bool
+
+	// DependsOn names other deployments (by DeploymentConfig.Name) that must
+	// be deployed before this one. Deployments are grouped into waves by
+	// their DependsOn relationship (see pkg/devspace/deploy's
+	// buildDeploymentWaves).
+	DependsOn []string
+	// Weight breaks ties between deployments that land in the same wave;
+	// lower weights deploy first, mirroring Helm's hook-weight semantics.
+	// Defaults to 0.
+	Weight int
+
+	// Targets deploys this deployment to one or more additional clusters
+	// instead of (or on top of) the default context/namespace, e.g. to roll
+	// the same deployment out to staging and prod in one run.
+	Targets []TargetRef
+
+	// RevisionHistoryLimit caps how many revisions of this deployment are
+	// kept (per target) for Rollback; defaults to
+	// deploy.DefaultMaxRevisionHistory when <= 0.
+	RevisionHistoryLimit int
+}
+
+// TargetRef names a single cluster a deployment should be applied to, on top
+// of (or instead of) the client's default context/namespace. Exactly one of
+// Context or KubeconfigSecret must be set.
+type TargetRef struct {
+	// Name identifies the target in logs and in deploy.MultiTargetError.
+	// Defaults to Context or KubeconfigSecret when empty.
+	Name string
+	// Context is the name of a context in the local/merged kubeconfig
+	Context string
+	// KubeconfigSecret references an in-cluster secret (namespace/name)
+	// holding a kubeconfig to use for this target
+	KubeconfigSecret string
+	// Namespace overrides the namespace deployed into for this target
+	Namespace string
+}
+
+func (t TargetRef) String() string {
+	switch {
+	case t.Name != "":
+		return t.Name
+	case t.Context != "":
+		return t.Context
+	default:
+		return t.KubeconfigSecret
+	}
+}
+
+// KubectlConfig deploys one or more local manifest files/globs with kubectl
+type KubectlConfig struct {
+	// Manifests lists local manifest file paths or globs to apply
+	Manifests []string
+}
+
+// HelmConfig deploys a Helm chart
+type HelmConfig struct {
+	// V2 selects Helm v2 (Tiller-based) instead of the default Helm v3 client
+	V2 bool
+	// TillerNamespace overrides the namespace devspace looks for Tiller in
+	// when V2 is set; defaults to the deploy client's namespace
+	TillerNamespace string
+
+	Chart       *ChartConfig
+	ValuesFiles []string
+}
+
+// ChartConfig names the chart a HelmConfig deploys
+type ChartConfig struct {
+	Name    string
+	Version string
+	RepoURL string
+}
+
+// KustomizeConfig deploys a kustomize overlay (see
+// pkg/devspace/deploy/deployer/kustomize)
+type KustomizeConfig struct {
+	Path string
+	Args []string
+}
+
+// ManifestsConfig deploys one or more remote manifest files fetched over
+// HTTP(S) (see pkg/devspace/deploy/deployer/manifests)
+type ManifestsConfig struct {
+	URLs []ManifestURL
+}
+
+// ManifestURL names a single remote manifest and, optionally, the checksum
+// its content is verified against before being applied
+type ManifestURL struct {
+	URL      string
+	Checksum string
+}
@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+
+	"github.com/pkg/errors"
+)
+
+// buildDeploymentWaves resolves the DependsOn / Weight relationships between
+// the given deployments into a list of waves that can each be executed in
+// parallel. Within a wave, ties are broken by ascending Weight and then by
+// name for determinism, mirroring Helm's hook-weight semantics (lower
+// weights run first).
+func buildDeploymentWaves(deployments []*latest.DeploymentConfig) ([][]*latest.DeploymentConfig, error) {
+	byName := make(map[string]*latest.DeploymentConfig, len(deployments))
+	for _, d := range deployments {
+		byName[d.Name] = d
+	}
+
+	indegree := make(map[string]int, len(deployments))
+	dependents := make(map[string][]string, len(deployments))
+	for _, d := range deployments {
+		indegree[d.Name] = 0
+	}
+
+	for _, d := range deployments {
+		for _, dep := range d.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, errors.Errorf("deployment %s depends on unknown deployment %s", d.Name, dep)
+			}
+
+			indegree[d.Name]++
+			dependents[dep] = append(dependents[dep], d.Name)
+		}
+	}
+
+	scheduled := make(map[string]bool, len(deployments))
+
+	var waves [][]*latest.DeploymentConfig
+	for len(scheduled) < len(deployments) {
+		var wave []*latest.DeploymentConfig
+		for _, d := range deployments {
+			if !scheduled[d.Name] && indegree[d.Name] == 0 {
+				wave = append(wave, d)
+			}
+		}
+
+		if len(wave) == 0 {
+			return nil, cycleError(deployments, scheduled)
+		}
+
+		sort.Slice(wave, func(i, j int) bool {
+			if wave[i].Weight != wave[j].Weight {
+				return wave[i].Weight < wave[j].Weight
+			}
+			return wave[i].Name < wave[j].Name
+		})
+
+		waves = append(waves, wave)
+
+		for _, d := range wave {
+			scheduled[d.Name] = true
+			for _, dependent := range dependents[d.Name] {
+				indegree[dependent]--
+			}
+		}
+	}
+
+	return waves, nil
+}
+
+// cycleError builds a descriptive error listing, sorted by name, every
+// deployment that is still waiting on an unresolved dependency: the members
+// of the cycle together with any deployment that depends on them
+func cycleError(deployments []*latest.DeploymentConfig, scheduled map[string]bool) error {
+	var cycle []string
+	for _, d := range deployments {
+		if !scheduled[d.Name] {
+			cycle = append(cycle, d.Name)
+		}
+	}
+
+	sort.Strings(cycle)
+	return errors.Errorf("deployments have a circular dependency: %s", strings.Join(cycle, " -> "))
+}
+
+// flattenWaves returns the deployments of every wave concatenated in
+// execution order
+func flattenWaves(waves [][]*latest.DeploymentConfig) []*latest.DeploymentConfig {
+	var flat []*latest.DeploymentConfig
+	for _, wave := range waves {
+		flat = append(flat, wave...)
+	}
+
+	return flat
+}
@@ -0,0 +1,121 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+)
+
+func waveNames(waves [][]*latest.DeploymentConfig) [][]string {
+	var names [][]string
+	for _, wave := range waves {
+		var waveNames []string
+		for _, d := range wave {
+			waveNames = append(waveNames, d.Name)
+		}
+		names = append(names, waveNames)
+	}
+	return names
+}
+
+func TestBuildDeploymentWavesNoDependencies(t *testing.T) {
+	deployments := []*latest.DeploymentConfig{
+		{Name: "b"},
+		{Name: "a"},
+	}
+
+	waves, err := buildDeploymentWaves(deployments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(waves) != 1 || len(waves[0]) != 2 {
+		t.Fatalf("expected a single wave with both deployments, got %v", waveNames(waves))
+	}
+	if waves[0][0].Name != "a" || waves[0][1].Name != "b" {
+		t.Fatalf("expected tie-break by name within a wave, got %v", waveNames(waves))
+	}
+}
+
+func TestBuildDeploymentWavesDependsOn(t *testing.T) {
+	deployments := []*latest.DeploymentConfig{
+		{Name: "frontend", DependsOn: []string{"backend"}},
+		{Name: "backend", DependsOn: []string{"db"}},
+		{Name: "db"},
+	}
+
+	waves, err := buildDeploymentWaves(deployments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := waveNames(waves)
+	want := [][]string{{"db"}, {"backend"}, {"frontend"}}
+	if len(got) != len(want) {
+		t.Fatalf("want waves %v, got %v", want, got)
+	}
+	for i := range want {
+		if len(got[i]) != 1 || got[i][0] != want[i][0] {
+			t.Fatalf("want waves %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBuildDeploymentWavesWeightTieBreak(t *testing.T) {
+	deployments := []*latest.DeploymentConfig{
+		{Name: "z", Weight: -5},
+		{Name: "a", Weight: 5},
+		{Name: "m"},
+	}
+
+	waves, err := buildDeploymentWaves(deployments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(waves) != 1 {
+		t.Fatalf("expected a single wave, got %v", waveNames(waves))
+	}
+
+	got := waveNames(waves)[0]
+	want := []string{"z", "m", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("want wave order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBuildDeploymentWavesUnknownDependency(t *testing.T) {
+	deployments := []*latest.DeploymentConfig{
+		{Name: "a", DependsOn: []string{"missing"}},
+	}
+
+	_, err := buildDeploymentWaves(deployments)
+	if err == nil {
+		t.Fatal("expected an error for an unknown dependency, got nil")
+	}
+}
+
+func TestBuildDeploymentWavesCycle(t *testing.T) {
+	deployments := []*latest.DeploymentConfig{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	_, err := buildDeploymentWaves(deployments)
+	if err == nil {
+		t.Fatal("expected a circular dependency error, got nil")
+	}
+}
+
+func TestFlattenWaves(t *testing.T) {
+	waves := [][]*latest.DeploymentConfig{
+		{{Name: "a"}, {Name: "b"}},
+		{{Name: "c"}},
+	}
+
+	flat := flattenWaves(waves)
+	if len(flat) != 3 || flat[0].Name != "a" || flat[1].Name != "b" || flat[2].Name != "c" {
+		t.Fatalf("unexpected flatten order: %v", flat)
+	}
+}
@@ -1,14 +1,18 @@
 package deploy
 
 import (
+	"bytes"
+	"context"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
 	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
-	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/helm"
-	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/diffutil"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/readiness"
 	helmclient "github.com/loft-sh/devspace/pkg/devspace/helm"
 	helmtypes "github.com/loft-sh/devspace/pkg/devspace/helm/types"
 	"github.com/loft-sh/devspace/pkg/devspace/hook"
@@ -25,6 +29,36 @@ type Options struct {
 	ForceDeploy bool
 	BuiltImages map[string]string
 	Deployments []string
+
+	// Parallelism limits how many deployments are deployed at the same time
+	// within a single dependency wave (see DeploymentConfig.DependsOn /
+	// Weight). Defaults to 1 (fully sequential) when <= 0.
+	Parallelism int
+
+	// Targets restricts which of a deployment's configured Targets (see
+	// DeploymentConfig.Targets) this run deploys to. Empty deploys to every
+	// configured target. Plumbed through the --target CLI flag.
+	Targets []string
+
+	// Timeout bounds how long Deploy waits for a deployment's objects to
+	// become ready when Wait is true. A DeploymentConfig.Timeout override
+	// takes precedence over this default.
+	Timeout time.Duration
+	// Wait makes Deploy block, after applying a deployment, until its
+	// objects are ready (mirroring Helm's --wait, applied uniformly across
+	// every deployer backend). A DeploymentConfig.Wait override takes
+	// precedence over this default.
+	Wait bool
+
+	// DryRun makes Controller.Diff send the rendered manifests to the API
+	// server with dryRun=All instead of deploying them, surfacing
+	// server-side admission errors (webhooks, OPA/Gatekeeper, CRD
+	// validation) before a real deploy.
+	DryRun bool
+	// DiffMode makes Controller.Diff fetch the live objects for each
+	// rendered manifest and print a structured diff against them instead of
+	// deploying.
+	DiffMode bool
 }
 
 // Controller is the main deploying interface
@@ -32,6 +66,12 @@ type Controller interface {
 	Deploy(options *Options, log log.Logger) error
 	Render(options *Options, out io.Writer, log log.Logger) error
 	Purge(deployments []string, log log.Logger) error
+	// Diff prints what Deploy would change without deploying, either via a
+	// server-side dry-run (options.DryRun) or a live diff (options.DiffMode)
+	Diff(options *Options, out io.Writer, log log.Logger) error
+	// Rollback reverts deployments (or every deployment when empty) to
+	// revision, or to the previous revision when revision is 0
+	Rollback(deployments []string, revision int, log log.Logger) error
 }
 
 type controller struct {
@@ -40,68 +80,113 @@ type controller struct {
 
 	hookExecuter hook.Executer
 	client       kubectlclient.Client
+
+	// deployerFactories holds the built-in deployer backends merged with any
+	// extraDeployers passed to NewController, keyed by deployConfig.Backend
+	deployerFactories map[string]DeployerFactory
+
+	// cacheMu guards c.cache.DeploymentRevisions, which recordRevision reads
+	// and writes from the same goroutines deployWave/deployOne spawn for
+	// parallel waves and multi-target fan-out.
+	cacheMu sync.Mutex
 }
 
-// NewController creates a new image build controller
-func NewController(config *latest.Config, cache *generated.CacheConfig, client kubectlclient.Client) Controller {
+// NewController creates a new deploy controller. extraDeployers is
+// optional and lets callers register additional deployer backends (beyond
+// the built-in kubectl, helm, kustomize and manifests ones) without having to
+// fork Deploy/Render/Purge; a backend registered here takes precedence over
+// the built-in kustomize or manifests backend of the same name. A deployment
+// with deployConfig.Kubectl or deployConfig.Helm set always uses the built-in
+// kubectl or helm deployer, whatever is registered.
+func NewController(config *latest.Config, cache *generated.CacheConfig, client kubectlclient.Client, extraDeployers map[string]DeployerFactory) Controller {
 	return &controller{
 		config: config,
 		cache:  cache,
 
 		hookExecuter: hook.NewExecuter(config),
 		client:       client,
+
+		deployerFactories: mergeDeployerFactories(extraDeployers),
 	}
 }
 
+// selectDeployments returns the deployments from c.config.Deployments whose
+// name is in names, preserving c.config.Deployments' order. An empty names
+// selects every deployment.
+func (c *controller) selectDeployments(names []string) []*latest.DeploymentConfig {
+	if len(names) == 0 {
+		return c.config.Deployments
+	}
+
+	var selected []*latest.DeploymentConfig
+	for _, deployConfig := range c.config.Deployments {
+		for _, name := range names {
+			if name == strings.TrimSpace(deployConfig.Name) {
+				selected = append(selected, deployConfig)
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
 func (c *controller) Render(options *Options, out io.Writer, log log.Logger) error {
 	if c.config.Deployments != nil && len(c.config.Deployments) > 0 {
 		helmV2Clients := map[string]helmtypes.Client{}
 
-		for _, deployConfig := range c.config.Deployments {
-			if len(options.Deployments) > 0 {
-				shouldSkip := true
-
-				for _, deployment := range options.Deployments {
-					if deployment == strings.TrimSpace(deployConfig.Name) {
-						shouldSkip = false
-						break
-					}
-				}
+		for _, deployConfig := range c.selectDeployments(options.Deployments) {
+			deployClient, _, err := c.getDeployer(deployConfig, helmV2Clients, true, log)
+			if err != nil {
+				return errors.Errorf("Error render: deployment %s error: %v", deployConfig.Name, err)
+			}
 
-				if shouldSkip {
-					continue
-				}
+			err = deployClient.Render(c.cache, options.BuiltImages, out)
+			if err != nil {
+				return errors.Errorf("Error deploying %s: %v", deployConfig.Name, err)
 			}
+		}
+	}
 
-			var (
-				deployClient deployer.Interface
-				err          error
-			)
+	return nil
+}
 
-			if deployConfig.Kubectl != nil {
-				deployClient, err = kubectl.New(c.config, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Errorf("Error render: deployment %s error: %v", deployConfig.Name, err)
-				}
+// Diff renders the selected deployments and either server-side dry-runs them
+// (options.DryRun) or diffs them against the live cluster state
+// (options.DiffMode), writing the result to out. It works for every deployer
+// backend, since dry-run reuses the existing kubectl client and the diff
+// itself is implemented by deployer.Interface.Diff.
+func (c *controller) Diff(options *Options, out io.Writer, log log.Logger) error {
+	if !options.DryRun && !options.DiffMode {
+		return errors.New("Diff requires either DryRun or DiffMode to be set")
+	}
 
-			} else if deployConfig.Helm != nil {
-				// Get helm client
-				helmClient, err := GetCachedHelmClient(c.config, deployConfig, c.client, helmV2Clients, true, log)
-				if err != nil {
-					return errors.Wrap(err, "get cached helm client")
-				}
+	if c.config.Deployments == nil || len(c.config.Deployments) == 0 {
+		return nil
+	}
 
-				deployClient, err = helm.New(c.config, helmClient, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Errorf("Error render: deployment %s error: %v", deployConfig.Name, err)
-				}
-			} else {
-				return errors.Errorf("Error render: deployment %s has no deployment method", deployConfig.Name)
+	helmV2Clients := map[string]helmtypes.Client{}
+
+	for _, deployConfig := range c.selectDeployments(options.Deployments) {
+		deployClient, _, err := c.getDeployer(deployConfig, helmV2Clients, true, log)
+		if err != nil {
+			return errors.Errorf("Error diff: deployment %s error: %v", deployConfig.Name, err)
+		}
+
+		if options.DryRun {
+			var buf bytes.Buffer
+			if err := deployClient.Render(c.cache, options.BuiltImages, &buf); err != nil {
+				return errors.Errorf("Error rendering %s: %v", deployConfig.Name, err)
 			}
 
-			err = deployClient.Render(c.cache, options.BuiltImages, out)
-			if err != nil {
-				return errors.Errorf("Error deploying %s: %v", deployConfig.Name, err)
+			if err := diffutil.DryRun(c.client, deployConfig.Namespace, buf.Bytes(), out); err != nil {
+				return errors.Errorf("Error dry-running %s: %v", deployConfig.Name, err)
+			}
+		}
+
+		if options.DiffMode {
+			if err := deployClient.Diff(c.cache, options.BuiltImages, out); err != nil {
+				return errors.Errorf("Error diffing %s: %v", deployConfig.Name, err)
 			}
 		}
 	}
@@ -109,98 +194,229 @@ func (c *controller) Render(options *Options, out io.Writer, log log.Logger) err
 	return nil
 }
 
-// DeployAll deploys all deployments in the config
+// Deploy deploys the deployments selected by options.Deployments (every
+// deployment in the config when empty). Deployments are grouped
+// into waves by their DependsOn/Weight relationship (see buildDeploymentWaves)
+// and every wave is executed by a worker pool sized by options.Parallelism;
+// the after-deployments hook only fires once every wave has finished.
 func (c *controller) Deploy(options *Options, log log.Logger) error {
-	if c.config.Deployments != nil && len(c.config.Deployments) > 0 {
+	selected := c.selectDeployments(options.Deployments)
+	if len(selected) > 0 {
 		helmV2Clients := map[string]helmtypes.Client{}
+		var helmMu sync.Mutex
 
-		// Execute before deployments deploy hook
-		err := c.hookExecuter.Execute(hook.Before, hook.StageDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+		waves, err := buildDeploymentWaves(selected)
 		if err != nil {
 			return err
 		}
 
-		for _, deployConfig := range c.config.Deployments {
-			if len(options.Deployments) > 0 {
-				shouldSkip := true
+		// Execute before deployments deploy hook
+		err = c.hookExecuter.Execute(hook.Before, hook.StageDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+		if err != nil {
+			return err
+		}
 
-				for _, deployment := range options.Deployments {
-					if deployment == strings.TrimSpace(deployConfig.Name) {
-						shouldSkip = false
-						break
-					}
-				}
+		parallelism := options.Parallelism
+		if parallelism <= 0 {
+			parallelism = 1
+		}
 
-				if shouldSkip {
-					continue
-				}
+		for _, wave := range waves {
+			if err := c.deployWave(wave, options, helmV2Clients, &helmMu, parallelism, log); err != nil {
+				return err
 			}
+		}
 
-			var (
-				deployClient deployer.Interface
-				err          error
-				method       string
-			)
+		// Execute after deployments deploy hook
+		err = c.hookExecuter.Execute(hook.After, hook.StageDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+		if err != nil {
+			return err
+		}
+	}
 
-			if deployConfig.Kubectl != nil {
-				deployClient, err = kubectl.New(c.config, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Errorf("Error deploying: deployment %s error: %v", deployConfig.Name, err)
-				}
+	return nil
+}
 
-				method = "kubectl"
-			} else if deployConfig.Helm != nil {
-				// Get helm client
-				helmClient, err := GetCachedHelmClient(c.config, deployConfig, c.client, helmV2Clients, false, log)
-				if err != nil {
-					return err
-				}
+// deployWave deploys every deployment in wave concurrently, bounded by
+// parallelism. Every deployment of the wave runs to completion even if
+// another one fails; the first error received is returned afterwards.
+func (c *controller) deployWave(wave []*latest.DeploymentConfig, options *Options, helmV2Clients map[string]helmtypes.Client, helmMu *sync.Mutex, parallelism int, log log.Logger) error {
+	sem := make(chan struct{}, parallelism)
+	errs := make(chan error, len(wave))
+	var wg sync.WaitGroup
 
-				deployClient, err = helm.New(c.config, helmClient, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Errorf("Error deploying: deployment %s error: %v", deployConfig.Name, err)
-				}
+	for _, deployConfig := range wave {
+		deployConfig := deployConfig
 
-				method = "helm"
-			} else {
-				return errors.Errorf("Error deploying: deployment %s has no deployment method", deployConfig.Name)
-			}
+		wg.Add(1)
+		sem <- struct{}{}
 
-			// Execute before deployment deploy hook
-			err = c.hookExecuter.Execute(hook.Before, hook.StageDeployments, deployConfig.Name, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
-			if err != nil {
-				return err
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs <- c.deployOne(deployConfig, options, helmV2Clients, helmMu, log)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deployOne deploys a single deployment. When deployConfig.Targets is empty
+// it deploys once against the controller's default cluster client (the
+// existing single-cluster path); otherwise it deploys concurrently to every
+// target selected by options.Targets and aggregates per-target failures into
+// a MultiTargetError. A deployment with Targets configured that none of them
+// match options.Targets (e.g. `--target prod` against a deployment only
+// targeting staging/qa) is skipped entirely rather than falling back to the
+// default cluster client.
+func (c *controller) deployOne(deployConfig *latest.DeploymentConfig, options *Options, helmV2Clients map[string]helmtypes.Client, helmMu *sync.Mutex, log log.Logger) error {
+	if len(deployConfig.Targets) == 0 {
+		return c.deployOneTarget(deployConfig, options, c.client, "", helmV2Clients, helmMu, log)
+	}
+
+	targets := selectTargets(deployConfig.Targets, options.Targets)
+	if len(targets) == 0 {
+		log.Infof("Skipping deployment %s: none of its targets match --target", deployConfig.Name)
+		return nil
+	}
+
+	errs := map[string]error{}
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, target := range targets {
+		target := target
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client, err := resolveTargetClient(c.client, target, log)
+			if err == nil {
+				err = c.deployOneTarget(deployConfig, options, client, target.String(), helmV2Clients, helmMu, log)
 			}
 
-			wasDeployed, err := deployClient.Deploy(c.cache, options.ForceDeploy, options.BuiltImages)
 			if err != nil {
-				c.hookExecuter.OnError(hook.StageDeployments, []string{hook.All, deployConfig.Name}, hook.Context{Client: c.client, Config: c.config, Cache: c.cache, Error: err}, log)
-				return errors.Errorf("Error deploying %s: %v", deployConfig.Name, err)
+				errsMu.Lock()
+				errs[target.String()] = err
+				errsMu.Unlock()
 			}
+		}()
+	}
 
-			if wasDeployed {
-				log.Donef("Successfully deployed %s with %s", deployConfig.Name, method)
+	wg.Wait()
 
-				// Execute after deployment deploy hook
-				err = c.hookExecuter.Execute(hook.After, hook.StageDeployments, deployConfig.Name, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
-				if err != nil {
-					return err
-				}
-			} else {
-				log.Infof("Skipping deployment %s", deployConfig.Name)
+	if len(errs) > 0 {
+		return &MultiTargetError{Deployment: deployConfig.Name, Errors: errs}
+	}
+
+	return nil
+}
+
+// deployOneTarget deploys a single deployment against a single cluster
+// client, firing its before/after/onerror hooks. It is safe to call
+// concurrently for different deployments/targets as long as helmMu guards the
+// shared helmV2Clients cache.
+func (c *controller) deployOneTarget(deployConfig *latest.DeploymentConfig, options *Options, client kubectlclient.Client, target string, helmV2Clients map[string]helmtypes.Client, helmMu *sync.Mutex, log log.Logger) error {
+	deployClient, method, err := c.getDeployerForClient(deployConfig, client, target, helmV2Clients, helmMu, false, log)
+	if err != nil {
+		return errors.Errorf("Error deploying: deployment %s error: %v", deployConfig.Name, err)
+	}
+
+	// Execute before deployment deploy hook
+	err = c.hookExecuter.Execute(hook.Before, hook.StageDeployments, deployConfig.Name, hook.Context{Client: client, Config: c.config, Cache: c.cache}, log)
+	if err != nil {
+		return err
+	}
+
+	timeout, wait := deploymentWaitSettings(deployConfig, options)
+
+	wasDeployed, err := deployClient.Deploy(c.cache, options.ForceDeploy, options.BuiltImages, timeout, wait)
+	if err != nil {
+		c.hookExecuter.OnError(hook.StageDeployments, []string{hook.All, deployConfig.Name}, hook.Context{Client: client, Config: c.config, Cache: c.cache, Error: err}, log)
+		return errors.Errorf("Error deploying %s: %v", deployConfig.Name, err)
+	}
+
+	if wasDeployed {
+		if target != "" {
+			log.Donef("Successfully deployed %s to %s with %s", deployConfig.Name, target, method)
+		} else {
+			log.Donef("Successfully deployed %s with %s", deployConfig.Name, method)
+		}
+
+		c.recordRevision(deployConfig, target, method, deployClient, options.BuiltImages, time.Now())
+
+		if wait {
+			if err := c.waitForReady(deployClient, client, deployConfig, timeout, log); err != nil {
+				c.hookExecuter.OnError(hook.StageDeployments, []string{hook.All, deployConfig.Name}, hook.Context{Client: client, Config: c.config, Cache: c.cache, Error: err}, log)
+				return errors.Errorf("Error waiting for %s to become ready: %v", deployConfig.Name, err)
 			}
 		}
 
-		// Execute after deployments deploy hook
-		err = c.hookExecuter.Execute(hook.After, hook.StageDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+		// Execute after deployment deploy hook
+		err = c.hookExecuter.Execute(hook.After, hook.StageDeployments, deployConfig.Name, hook.Context{Client: client, Config: c.config, Cache: c.cache}, log)
 		if err != nil {
 			return err
 		}
+	} else {
+		log.Infof("Skipping deployment %s", deployConfig.Name)
 	}
 
 	return nil
 }
 
+// deploymentWaitSettings resolves the effective timeout/wait for a single
+// deployment: a DeploymentConfig override takes precedence over the run-wide
+// Options default.
+func deploymentWaitSettings(deployConfig *latest.DeploymentConfig, options *Options) (time.Duration, bool) {
+	timeout := options.Timeout
+	if deployConfig.Timeout > 0 {
+		timeout = deployConfig.Timeout
+	}
+
+	wait := options.Wait
+	if deployConfig.Wait != nil {
+		wait = *deployConfig.Wait
+	}
+
+	return timeout, wait
+}
+
+// waitForReady blocks until the objects applied for deployConfig are ready,
+// using deployClient.DeployedObjects() when the backend implements
+// deployer.ObjectLister (kustomize, manifests, kubectl); backends that block
+// until ready on their own (e.g. helm with --wait) do not implement it and
+// waitForReady is a no-op for them.
+func (c *controller) waitForReady(deployClient deployer.Interface, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, timeout time.Duration, log log.Logger) error {
+	lister, ok := deployClient.(deployer.ObjectLister)
+	if !ok {
+		return nil
+	}
+
+	objects := lister.DeployedObjects()
+	if len(objects) == 0 {
+		return nil
+	}
+
+	return readiness.Wait(context.Background(), readiness.Options{
+		Client:    client,
+		Namespace: deployConfig.Namespace,
+		Objects:   objects,
+		Timeout:   timeout,
+		Log:       log,
+	})
+}
+
 // Purge removes all deployments or a set of deployments from the cluster
 func (c *controller) Purge(deployments []string, log log.Logger) error {
 	if deployments != nil && len(deployments) == 0 {
@@ -216,13 +432,16 @@ func (c *controller) Purge(deployments []string, log log.Logger) error {
 			return err
 		}
 
-		// Reverse them
-		for i := len(c.config.Deployments) - 1; i >= 0; i-- {
-			var (
-				err          error
-				deployClient deployer.Interface
-				deployConfig = c.config.Deployments[i]
-			)
+		waves, err := buildDeploymentWaves(c.config.Deployments)
+		if err != nil {
+			return err
+		}
+
+		// Purge in reverse topological order: dependents before their
+		// dependencies
+		ordered := flattenWaves(waves)
+		for i := len(ordered) - 1; i >= 0; i-- {
+			deployConfig := ordered[i]
 
 			// Check if we should skip deleting deployment
 			if deployments != nil {
@@ -240,24 +459,9 @@ func (c *controller) Purge(deployments []string, log log.Logger) error {
 				}
 			}
 
-			// Delete kubectl engine
-			if deployConfig.Kubectl != nil {
-				deployClient, err = kubectl.New(c.config, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Wrap(err, "create kube client")
-				}
-			} else if deployConfig.Helm != nil {
-				helmClient, err := GetCachedHelmClient(c.config, deployConfig, c.client, helmV2Clients, false, log)
-				if err != nil {
-					return errors.Wrap(err, "get cached helm client")
-				}
-
-				deployClient, err = helm.New(c.config, helmClient, c.client, deployConfig, log)
-				if err != nil {
-					return errors.Wrap(err, "create helm client")
-				}
-			} else {
-				return errors.Errorf("Error purging: deployment %s has no deployment method", deployConfig.Name)
+			deployClient, _, err := c.getDeployer(deployConfig, helmV2Clients, false, log)
+			if err != nil {
+				return errors.Wrap(err, "create deploy client")
 			}
 
 			// Execute before deployment purge hook
@@ -298,16 +502,22 @@ func (c *controller) Purge(deployments []string, log log.Logger) error {
 	return nil
 }
 
-// GetCachedHelmClient returns a helm client that could be cached in a helmV2Clients map. If not found it will add it to the map and create it
-func GetCachedHelmClient(config *latest.Config, deployConfig *latest.DeploymentConfig, client kubectlpkg.Client, helmV2Clients map[string]helmtypes.Client, dryInit bool, log log.Logger) (helmtypes.Client, error) {
+// GetCachedHelmClient returns a helm client that could be cached in a
+// helmV2Clients map. If not found it will add it to the map and create it.
+// helmV2Clients is keyed per (target, tillerNamespace) rather than just
+// tillerNamespace, since the same tiller namespace name can exist
+// independently in more than one target cluster; target is empty for the
+// default/single-cluster path.
+func GetCachedHelmClient(config *latest.Config, deployConfig *latest.DeploymentConfig, client kubectlpkg.Client, target string, helmV2Clients map[string]helmtypes.Client, dryInit bool, log log.Logger) (helmtypes.Client, error) {
 	var (
 		err        error
 		helmClient helmtypes.Client
 	)
 
 	tillerNamespace := getTillernamespace(client, deployConfig)
-	if tillerNamespace != "" && helmV2Clients[tillerNamespace] != nil {
-		helmClient = helmV2Clients[tillerNamespace]
+	cacheKey := target + "|" + tillerNamespace
+	if tillerNamespace != "" && helmV2Clients[cacheKey] != nil {
+		helmClient = helmV2Clients[cacheKey]
 	} else {
 		helmClient, err = helmclient.NewClient(config, deployConfig, client, tillerNamespace, false, dryInit, log)
 		if err != nil {
@@ -315,7 +525,7 @@ func GetCachedHelmClient(config *latest.Config, deployConfig *latest.DeploymentC
 		}
 
 		if tillerNamespace != "" {
-			helmV2Clients[tillerNamespace] = helmClient
+			helmV2Clients[cacheKey] = helmClient
 		}
 	}
 
@@ -0,0 +1,128 @@
+// Package diffutil provides the shared server-side dry-run and live-diff
+// plumbing used by deployer backends that implement deployer.Interface.Diff
+// by re-rendering their existing manifest pipeline (kustomize, manifests;
+// kubectl and helm reuse it the same way against their own rendered output).
+package diffutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// DryRun sends manifests to the API server with dryRun=All and writes the
+// result (including any webhook/OPA/CRD validation errors) to out.
+func DryRun(client kubectlclient.Client, namespace string, manifests []byte, out io.Writer) error {
+	result, err := client.ApplyManifestsDryRun(manifests, namespace)
+	if err != nil {
+		fmt.Fprintf(out, "%sdry-run rejected by the API server: %v%s\n", colorRed, err, colorReset)
+		return err
+	}
+
+	fmt.Fprintln(out, result)
+	return nil
+}
+
+// Diff fetches the live state of every object described in manifests and
+// writes a colorized unified diff against the rendered manifests to out,
+// similar to `kubectl diff`.
+func Diff(client kubectlclient.Client, namespace string, manifests []byte, out io.Writer) error {
+	for _, doc := range splitDocuments(manifests) {
+		refs := deployer.ParseObjectRefs([]byte(doc))
+		if len(refs) == 0 {
+			continue
+		}
+		ref := refs[0]
+
+		liveYAML, err := liveObjectYAML(client, namespace, ref)
+		if err != nil {
+			return err
+		}
+
+		diff := difflib.UnifiedDiff{
+			A:        difflib.SplitLines(liveYAML),
+			B:        difflib.SplitLines(doc),
+			FromFile: fmt.Sprintf("%s/%s (live)", ref.Kind, ref.Name),
+			ToFile:   fmt.Sprintf("%s/%s (rendered)", ref.Kind, ref.Name),
+			Context:  3,
+		}
+
+		text, err := difflib.GetUnifiedDiffString(diff)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(text) == "" {
+			fmt.Fprintf(out, "%s%s/%s is up to date%s\n", colorCyan, ref.Kind, ref.Name, colorReset)
+			continue
+		}
+
+		fmt.Fprint(out, colorize(text))
+	}
+
+	return nil
+}
+
+// liveObjectYAML fetches ref from the cluster and marshals it back to YAML
+// so it can be diffed against the rendered manifest; a missing object diffs
+// against an empty document, matching `kubectl diff`'s "will be created"
+// behavior. Any other error (RBAC, network, ...) is propagated rather than
+// silently treated as "doesn't exist yet".
+func liveObjectYAML(client kubectlclient.Client, namespace string, ref deployer.ObjectRef) (string, error) {
+	obj, err := client.GetObject(ref.Kind, ref.Name, namespace)
+	if kerrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "get live object")
+	}
+
+	live, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(live), nil
+}
+
+func splitDocuments(manifests []byte) []string {
+	var docs []string
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		doc = strings.TrimSpace(doc)
+		if doc != "" {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs
+}
+
+func colorize(unifiedDiff string) string {
+	var colored strings.Builder
+	for _, line := range strings.Split(unifiedDiff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			colored.WriteString(colorGreen + line + colorReset + "\n")
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			colored.WriteString(colorRed + line + colorReset + "\n")
+		default:
+			colored.WriteString(line + "\n")
+		}
+	}
+
+	return colored.String()
+}
@@ -0,0 +1,40 @@
+package diffutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	manifests := []byte("kind: ConfigMap\nname: a\n---\n\n---\nkind: Secret\nname: b\n")
+
+	docs := splitDocuments(manifests)
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d: %v", len(docs), docs)
+	}
+	if !strings.Contains(docs[0], "ConfigMap") || !strings.Contains(docs[1], "Secret") {
+		t.Fatalf("unexpected document contents: %v", docs)
+	}
+}
+
+func TestSplitDocumentsEmpty(t *testing.T) {
+	if docs := splitDocuments([]byte("\n---\n   \n")); len(docs) != 0 {
+		t.Fatalf("expected no documents, got %v", docs)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	diff := "context\n+added\n-removed\n+++ b/file\n--- a/file\n"
+
+	colored := colorize(diff)
+
+	if !strings.Contains(colored, colorGreen+"+added"+colorReset) {
+		t.Errorf("expected added line to be colored green, got %q", colored)
+	}
+	if !strings.Contains(colored, colorRed+"-removed"+colorReset) {
+		t.Errorf("expected removed line to be colored red, got %q", colored)
+	}
+	if strings.Contains(colored, colorGreen+"+++") || strings.Contains(colored, colorRed+"---") {
+		t.Errorf("file header lines must not be colored, got %q", colored)
+	}
+}
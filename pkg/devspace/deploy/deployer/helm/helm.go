@@ -0,0 +1,74 @@
+// Package helm deploys a Helm chart through a cached helm client (see
+// pkg/devspace/helm), adapting its helmtypes.Client shape to
+// deployer.Interface so the controller in pkg/devspace/deploy doesn't need
+// to special-case Helm deployments.
+package helm
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/diffutil"
+	helmtypes "github.com/loft-sh/devspace/pkg/devspace/helm/types"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DeployConfig deploys a Helm chart via an already-constructed helmtypes.Client
+type DeployConfig struct {
+	config       *latest.Config
+	helmClient   helmtypes.Client
+	client       kubectlclient.Client
+	deployConfig *latest.DeploymentConfig
+	log          log.Logger
+}
+
+// New creates a new helm deployer for the given deployment config, wrapping
+// the helm client the controller already resolved (see
+// GetCachedHelmClient in pkg/devspace/deploy)
+func New(config *latest.Config, helmClient helmtypes.Client, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (*DeployConfig, error) {
+	if deployConfig.Helm == nil {
+		return nil, errors.Errorf("deployment %s: helm config is required", deployConfig.Name)
+	}
+
+	return &DeployConfig{
+		config:       config,
+		helmClient:   helmClient,
+		client:       client,
+		deployConfig: deployConfig,
+		log:          log,
+	}, nil
+}
+
+// Render writes the templated chart output to out without installing it
+func (d *DeployConfig) Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	return d.helmClient.Render(cache, builtImages, out)
+}
+
+// Deploy installs or upgrades the release. wait/timeout are forwarded
+// directly to the helm client instead of the readiness subsystem, since
+// Helm's own --wait blocks until the release is ready on its own.
+func (d *DeployConfig) Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error) {
+	return d.helmClient.Deploy(cache, forceDeploy, builtImages, timeout, wait)
+}
+
+// Delete uninstalls the release
+func (d *DeployConfig) Delete(cache *generated.CacheConfig) error {
+	return d.helmClient.Delete(cache)
+}
+
+// Diff renders the chart through the helm client's Render and diffs the
+// rendered manifests against the corresponding live objects
+func (d *DeployConfig) Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	var buf bytes.Buffer
+	if err := d.helmClient.Render(cache, builtImages, &buf); err != nil {
+		return err
+	}
+
+	return diffutil.Diff(d.client, d.deployConfig.Namespace, buf.Bytes(), out)
+}
@@ -0,0 +1,75 @@
+package deployer
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Interface defines the common interface a deployment backend has to
+// implement so the controller in pkg/devspace/deploy can render, deploy and
+// delete it without knowing anything about the underlying tool (kubectl,
+// helm, kustomize, ...)
+type Interface interface {
+	Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error
+	// Deploy applies the deployment. When wait is true, the caller blocks
+	// (via the readiness subsystem, see pkg/devspace/deploy/readiness) until
+	// the deployed objects are ready or timeout elapses; backends that
+	// already block until ready on their own (e.g. helm with --wait) may
+	// ignore timeout/wait.
+	Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error)
+	Delete(cache *generated.CacheConfig) error
+	// Diff writes a colorized unified diff between the backend's rendered
+	// manifests and the corresponding live objects to out (see
+	// pkg/devspace/deploy/deployer/diffutil, which every built-in backend
+	// uses to implement this against its own render/template pipeline).
+	Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error
+}
+
+// ObjectRef names a single Kubernetes object a backend applied
+type ObjectRef struct {
+	Kind string
+	Name string
+}
+
+// ObjectLister is implemented by deployer backends that can report which
+// objects they just applied, so the controller's readiness subsystem (see
+// pkg/devspace/deploy/readiness) knows what to wait on. Backends that block
+// until ready on their own (e.g. helm with --wait) do not need to implement it.
+type ObjectLister interface {
+	DeployedObjects() []ObjectRef
+}
+
+// ParseObjectRefs extracts the Kind/Name of every object in a multi-document
+// YAML manifest stream. Documents that fail to parse or have no kind are
+// skipped rather than erroring, since readiness treats the result as a
+// best-effort hint of what to watch.
+func ParseObjectRefs(manifests []byte) []ObjectRef {
+	var refs []ObjectRef
+
+	for _, doc := range strings.Split(string(manifests), "\n---") {
+		doc = strings.TrimSpace(doc)
+		if doc == "" {
+			continue
+		}
+
+		var obj struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+			continue
+		}
+
+		refs = append(refs, ObjectRef{Kind: obj.Kind, Name: obj.Metadata.Name})
+	}
+
+	return refs
+}
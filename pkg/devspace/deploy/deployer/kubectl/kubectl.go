@@ -0,0 +1,143 @@
+package kubectl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/diffutil"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DeployConfig reads one or more local manifest files/globs and applies them
+// through the existing kubectl client
+type DeployConfig struct {
+	config       *latest.Config
+	client       kubectlclient.Client
+	deployConfig *latest.DeploymentConfig
+	log          log.Logger
+
+	// lastManifests and lastObjects cache the output of the most recent
+	// Deploy call, so DeployedObjects/LastManifests can report it to the
+	// readiness and rollback subsystems
+	lastManifests []byte
+	lastObjects   []deployer.ObjectRef
+}
+
+// New creates a new kubectl deployer for the given deployment config
+func New(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (*DeployConfig, error) {
+	if deployConfig.Kubectl == nil || len(deployConfig.Kubectl.Manifests) == 0 {
+		return nil, errors.Errorf("deployment %s: kubectl.manifests is required", deployConfig.Name)
+	}
+
+	return &DeployConfig{
+		config:       config,
+		client:       client,
+		deployConfig: deployConfig,
+		log:          log,
+	}, nil
+}
+
+// read resolves every configured manifest glob and concatenates the matched
+// files into a single manifest stream
+func (d *DeployConfig) read() ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, pattern := range d.deployConfig.Kubectl.Manifests {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Errorf("manifest pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("manifest pattern %s matched no files", pattern)
+		}
+
+		for _, match := range matches {
+			content, err := os.ReadFile(match)
+			if err != nil {
+				return nil, errors.Errorf("read manifest %s: %v", match, err)
+			}
+
+			buf.Write(content)
+			buf.WriteString("\n---\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Render resolves the configured manifest globs and writes the matched files
+// to out
+func (d *DeployConfig) Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(manifests)
+	return err
+}
+
+// Deploy resolves the configured manifest globs and applies them through the
+// kubectl client. Waiting for the applied objects to become ready is handled
+// centrally by the deploy controller's readiness subsystem, so timeout/wait
+// are not used here.
+func (d *DeployConfig) Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error) {
+	manifests, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	err = d.client.ApplyManifests(manifests, d.deployConfig.Namespace)
+	if err != nil {
+		return false, errors.Wrap(err, "apply manifests")
+	}
+
+	d.lastManifests = manifests
+	d.lastObjects = deployer.ParseObjectRefs(manifests)
+
+	return true, nil
+}
+
+// DeployedObjects returns the objects applied by the most recent Deploy
+// call, so the controller's readiness subsystem knows what to wait on
+func (d *DeployConfig) DeployedObjects() []deployer.ObjectRef {
+	return d.lastObjects
+}
+
+// LastManifests returns the manifests applied by the most recent Deploy
+// call, so the controller can persist them into the deployment's revision
+// history for Rollback
+func (d *DeployConfig) LastManifests() []byte {
+	return d.lastManifests
+}
+
+// Diff resolves the configured manifest globs and diffs them against the
+// corresponding live objects
+func (d *DeployConfig) Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	return diffutil.Diff(d.client, d.deployConfig.Namespace, manifests, out)
+}
+
+// Delete resolves the configured manifest globs and deletes the resulting
+// objects from the cluster
+func (d *DeployConfig) Delete(cache *generated.CacheConfig) error {
+	manifests, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	return d.client.DeleteManifests(manifests, d.deployConfig.Namespace)
+}
@@ -0,0 +1,133 @@
+package kustomize
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/diffutil"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DeployConfig holds the information necessary to deploy a kustomize overlay
+type DeployConfig struct {
+	config       *latest.Config
+	client       kubectlclient.Client
+	deployConfig *latest.DeploymentConfig
+	log          log.Logger
+
+	// lastManifests and lastObjects cache the output of the most recent
+	// Deploy call, so DeployedObjects/LastManifests can report it to the
+	// readiness and rollback subsystems
+	lastManifests []byte
+	lastObjects   []deployer.ObjectRef
+}
+
+// New creates a new kustomize deployer for the given deployment config
+func New(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (*DeployConfig, error) {
+	if deployConfig.Kustomize == nil || deployConfig.Kustomize.Path == "" {
+		return nil, errors.Errorf("deployment %s: kustomize.path is required", deployConfig.Name)
+	}
+
+	return &DeployConfig{
+		config:       config,
+		client:       client,
+		deployConfig: deployConfig,
+		log:          log,
+	}, nil
+}
+
+// build runs `kustomize build` against the configured overlay directory and
+// returns the rendered manifests
+func (d *DeployConfig) build() ([]byte, error) {
+	args := []string{"build", d.deployConfig.Kustomize.Path}
+	if len(d.deployConfig.Kustomize.Args) > 0 {
+		args = append(args, d.deployConfig.Kustomize.Args...)
+	}
+
+	cmd := exec.Command("kustomize", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("kustomize build %s: %v (%s)", d.deployConfig.Kustomize.Path, err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Render builds the kustomize overlay and writes the rendered manifests to out
+func (d *DeployConfig) Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.build()
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(manifests)
+	return err
+}
+
+// Deploy builds the kustomize overlay and applies the rendered manifests
+// through the existing kubectl client. Waiting for the applied objects to
+// become ready is handled centrally by the deploy controller's readiness
+// subsystem, so timeout/wait are not used here.
+func (d *DeployConfig) Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error) {
+	manifests, err := d.build()
+	if err != nil {
+		return false, err
+	}
+
+	err = d.client.ApplyManifests(manifests, d.deployConfig.Namespace)
+	if err != nil {
+		return false, errors.Wrap(err, "apply kustomize output")
+	}
+
+	d.lastManifests = manifests
+	d.lastObjects = deployer.ParseObjectRefs(manifests)
+
+	return true, nil
+}
+
+// DeployedObjects returns the objects applied by the most recent Deploy
+// call, so the controller's readiness subsystem knows what to wait on
+func (d *DeployConfig) DeployedObjects() []deployer.ObjectRef {
+	return d.lastObjects
+}
+
+// LastManifests returns the manifests applied by the most recent Deploy
+// call, so the controller can persist them into the deployment's revision
+// history for Rollback
+func (d *DeployConfig) LastManifests() []byte {
+	return d.lastManifests
+}
+
+// Diff builds the kustomize overlay and diffs the rendered manifests against
+// the corresponding live objects
+func (d *DeployConfig) Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.build()
+	if err != nil {
+		return err
+	}
+
+	return diffutil.Diff(d.client, d.deployConfig.Namespace, manifests, out)
+}
+
+// Delete builds the kustomize overlay and deletes the rendered objects from
+// the cluster
+func (d *DeployConfig) Delete(cache *generated.CacheConfig) error {
+	manifests, err := d.build()
+	if err != nil {
+		return err
+	}
+
+	return d.client.DeleteManifests(manifests, d.deployConfig.Namespace)
+}
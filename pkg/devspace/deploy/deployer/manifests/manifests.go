@@ -0,0 +1,156 @@
+package manifests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/diffutil"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DeployConfig fetches raw YAML/JSON manifests from one or more HTTP(S) URLs
+// and applies them through the existing kubectl client
+type DeployConfig struct {
+	config       *latest.Config
+	client       kubectlclient.Client
+	deployConfig *latest.DeploymentConfig
+	log          log.Logger
+
+	httpClient *http.Client
+
+	// lastManifests and lastObjects cache the output of the most recent
+	// Deploy call, so DeployedObjects/LastManifests can report it to the
+	// readiness and rollback subsystems
+	lastManifests []byte
+	lastObjects   []deployer.ObjectRef
+}
+
+// New creates a new manifests deployer for the given deployment config
+func New(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (*DeployConfig, error) {
+	if deployConfig.Manifests == nil || len(deployConfig.Manifests.URLs) == 0 {
+		return nil, errors.Errorf("deployment %s: manifests.urls is required", deployConfig.Name)
+	}
+
+	return &DeployConfig{
+		config:       config,
+		client:       client,
+		deployConfig: deployConfig,
+		log:          log,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// fetch downloads every configured manifest URL, verifies its checksum (when
+// one is configured) and concatenates the results into a single manifest
+// stream
+func (d *DeployConfig) fetch() ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, manifest := range d.deployConfig.Manifests.URLs {
+		resp, err := d.httpClient.Get(manifest.URL)
+		if err != nil {
+			return nil, errors.Errorf("fetch manifest %s: %v", manifest.URL, err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Errorf("read manifest %s: %v", manifest.URL, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("fetch manifest %s: unexpected status %s", manifest.URL, resp.Status)
+		}
+
+		if manifest.Checksum == "" {
+			d.log.Warnf("manifest %s has no checksum configured, its content will not be verified", manifest.URL)
+		} else {
+			sum := sha256.Sum256(body)
+			if hex.EncodeToString(sum[:]) != manifest.Checksum {
+				return nil, errors.Errorf("manifest %s: checksum mismatch, refusing to deploy untrusted content", manifest.URL)
+			}
+		}
+
+		buf.Write(body)
+		buf.WriteString("\n---\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Render fetches the configured manifest URLs and writes them to out
+func (d *DeployConfig) Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.fetch()
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(manifests)
+	return err
+}
+
+// Deploy fetches the configured manifest URLs and applies them through the
+// kubectl client. Waiting for the applied objects to become ready is handled
+// centrally by the deploy controller's readiness subsystem, so timeout/wait
+// are not used here.
+func (d *DeployConfig) Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error) {
+	manifests, err := d.fetch()
+	if err != nil {
+		return false, err
+	}
+
+	err = d.client.ApplyManifests(manifests, d.deployConfig.Namespace)
+	if err != nil {
+		return false, errors.Wrap(err, "apply manifests")
+	}
+
+	d.lastManifests = manifests
+	d.lastObjects = deployer.ParseObjectRefs(manifests)
+
+	return true, nil
+}
+
+// DeployedObjects returns the objects applied by the most recent Deploy
+// call, so the controller's readiness subsystem knows what to wait on
+func (d *DeployConfig) DeployedObjects() []deployer.ObjectRef {
+	return d.lastObjects
+}
+
+// LastManifests returns the manifests applied by the most recent Deploy
+// call, so the controller can persist them into the deployment's revision
+// history for Rollback
+func (d *DeployConfig) LastManifests() []byte {
+	return d.lastManifests
+}
+
+// Diff fetches the configured manifest URLs and diffs them against the
+// corresponding live objects
+func (d *DeployConfig) Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	manifests, err := d.fetch()
+	if err != nil {
+		return err
+	}
+
+	return diffutil.Diff(d.client, d.deployConfig.Namespace, manifests, out)
+}
+
+// Delete fetches the configured manifest URLs and deletes the resulting
+// objects from the cluster
+func (d *DeployConfig) Delete(cache *generated.CacheConfig) error {
+	manifests, err := d.fetch()
+	if err != nil {
+		return err
+	}
+
+	return d.client.DeleteManifests(manifests, d.deployConfig.Namespace)
+}
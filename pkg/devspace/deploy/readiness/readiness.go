@@ -0,0 +1,262 @@
+// Package readiness implements a wait-for-ready subsystem that mirrors
+// Helm's --wait behavior, but applies uniformly to every deployer backend
+// (kubectl, kustomize, manifests, ...) instead of just Helm releases.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// DefaultPollInterval is how often Wait re-checks the deployed objects while
+// they are not yet ready
+const DefaultPollInterval = 2 * time.Second
+
+// eventHistoryLimit bounds how many of the most recent events involving the
+// not-ready object itself are attached to a NotReadyError's ResourceStatus;
+// events of the object's pods are not attached
+const eventHistoryLimit = 5
+
+// Options configures a single Wait call
+type Options struct {
+	Client    kubectlclient.Client
+	Namespace string
+	// Objects restricts Wait to the given object references. Only objects of
+	// Kind Deployment, StatefulSet, DaemonSet, Job, PersistentVolumeClaim or
+	// Service are checked; objects of any other Kind are ignored.
+	Objects      []deployer.ObjectRef
+	Timeout      time.Duration
+	PollInterval time.Duration
+	Log          log.Logger
+}
+
+// ResourceStatus describes why a single object was not ready when Wait gave up
+type ResourceStatus struct {
+	Kind       string
+	Name       string
+	Reason     string
+	Events     []string
+	Containers []string
+}
+
+// NotReadyError is returned when Wait times out before every object became
+// ready. It carries enough detail (the object's own most recent events, the
+// container statuses of its pods) for
+// hook.OnError to surface a structured error to the user.
+type NotReadyError struct {
+	Resources []ResourceStatus
+}
+
+func (e *NotReadyError) Error() string {
+	msg := fmt.Sprintf("%d resource(s) did not become ready in time", len(e.Resources))
+	for _, r := range e.Resources {
+		msg += fmt.Sprintf("\n  - %s/%s: %s", r.Kind, r.Name, r.Reason)
+		for _, c := range r.Containers {
+			msg += fmt.Sprintf("\n      container: %s", c)
+		}
+		for _, ev := range r.Events {
+			msg += fmt.Sprintf("\n      event: %s", ev)
+		}
+	}
+
+	return msg
+}
+
+// Wait blocks until every object in options.Objects is ready, or returns a
+// *NotReadyError once options.Timeout elapses. Readiness per kind:
+//   - Deployment / StatefulSet: ready replicas >= desired replicas
+//   - DaemonSet: NumberReady == DesiredNumberScheduled
+//   - Job: Succeeded
+//   - PersistentVolumeClaim: phase Bound
+//   - Service (LoadBalancer): at least one ingress address assigned
+func Wait(ctx context.Context, options Options) error {
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Minute
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		notReady, err := checkObjects(ctx, options)
+		if err != nil {
+			return err
+		}
+
+		if len(notReady) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &NotReadyError{Resources: notReady}
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkObjects(ctx context.Context, options Options) ([]ResourceStatus, error) {
+	kubeClient := options.Client.KubeClient()
+
+	var notReady []ResourceStatus
+	for _, object := range options.Objects {
+		switch object.Kind {
+		case "Deployment":
+			deployment, err := kubeClient.AppsV1().Deployments(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if deployment.Status.ReadyReplicas < getReplicas(deployment.Spec.Replicas) {
+				notReady = append(notReady, options.describe(ctx, "Deployment", object.Name, fmt.Sprintf("%d/%d replicas ready", deployment.Status.ReadyReplicas, getReplicas(deployment.Spec.Replicas)), labelSelectorString(deployment.Spec.Selector)))
+			}
+
+		case "StatefulSet":
+			statefulSet, err := kubeClient.AppsV1().StatefulSets(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if statefulSet.Status.ReadyReplicas < getReplicas(statefulSet.Spec.Replicas) {
+				notReady = append(notReady, options.describe(ctx, "StatefulSet", object.Name, fmt.Sprintf("%d/%d replicas ready", statefulSet.Status.ReadyReplicas, getReplicas(statefulSet.Spec.Replicas)), labelSelectorString(statefulSet.Spec.Selector)))
+			}
+
+		case "DaemonSet":
+			daemonSet, err := kubeClient.AppsV1().DaemonSets(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+				notReady = append(notReady, options.describe(ctx, "DaemonSet", object.Name, fmt.Sprintf("%d/%d pods ready", daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled), labelSelectorString(daemonSet.Spec.Selector)))
+			}
+
+		case "Job":
+			job, err := kubeClient.BatchV1().Jobs(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if !jobSucceeded(job) {
+				notReady = append(notReady, options.describe(ctx, "Job", object.Name, "job has not succeeded yet", labelSelectorString(job.Spec.Selector)))
+			}
+
+		case "PersistentVolumeClaim":
+			pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if pvc.Status.Phase != corev1.ClaimBound {
+				notReady = append(notReady, options.describe(ctx, "PersistentVolumeClaim", object.Name, fmt.Sprintf("phase is %s, expected Bound", pvc.Status.Phase), ""))
+			}
+
+		case "Service":
+			service, err := kubeClient.CoreV1().Services(options.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if service.Spec.Type == corev1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+				notReady = append(notReady, options.describe(ctx, "Service", object.Name, "no load balancer ingress address assigned yet", labelSelectorString(&metav1.LabelSelector{MatchLabels: service.Spec.Selector})))
+			}
+		}
+	}
+
+	return notReady, nil
+}
+
+func getReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+func jobSucceeded(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobComplete && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describe builds a ResourceStatus for a not-yet-ready object, attaching the
+// container statuses of its own pods (found via labelSelector, the object's
+// own pod selector; empty for kinds with no owned pods, e.g.
+// PersistentVolumeClaim) and the most recent events involving the object
+// itself, rather than every pod/event in the namespace, so an unrelated
+// failure elsewhere doesn't get attributed to this object.
+func (o Options) describe(ctx context.Context, kind, name, reason, labelSelector string) ResourceStatus {
+	status := ResourceStatus{Kind: kind, Name: name, Reason: reason}
+
+	if labelSelector != "" {
+		pods, err := o.Client.KubeClient().CoreV1().Pods(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err == nil {
+			for _, pod := range pods.Items {
+				for _, containerStatus := range pod.Status.ContainerStatuses {
+					if !containerStatus.Ready {
+						status.Containers = append(status.Containers, fmt.Sprintf("%s/%s: %s", pod.Name, containerStatus.Name, containerStatusReason(containerStatus)))
+					}
+				}
+			}
+		}
+	}
+
+	fieldSelector := fields.AndSelectors(
+		fields.OneTermEqualSelector("involvedObject.kind", kind),
+		fields.OneTermEqualSelector("involvedObject.name", name),
+	).String()
+
+	events, err := o.Client.KubeClient().CoreV1().Events(o.Namespace).List(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+	if err == nil {
+		count := 0
+		for i := len(events.Items) - 1; i >= 0 && count < eventHistoryLimit; i-- {
+			event := events.Items[i]
+			status.Events = append(status.Events, fmt.Sprintf("%s: %s", event.Reason, event.Message))
+			count++
+		}
+	}
+
+	return status
+}
+
+// labelSelectorString formats selector for use as a List call's
+// LabelSelector, returning "" (meaning "match everything") when selector is
+// nil, matching kinds that don't select pods at all.
+func labelSelectorString(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+
+	formatted, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return ""
+	}
+
+	return formatted.String()
+}
+
+func containerStatusReason(status corev1.ContainerStatus) string {
+	if status.State.Waiting != nil {
+		return fmt.Sprintf("waiting (%s): %s", status.State.Waiting.Reason, status.State.Waiting.Message)
+	}
+	if status.State.Terminated != nil {
+		return fmt.Sprintf("terminated (%s): %s", status.State.Terminated.Reason, status.State.Terminated.Message)
+	}
+
+	return "not ready"
+}
@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/helm"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/kustomize"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer/manifests"
+	helmtypes "github.com/loft-sh/devspace/pkg/devspace/helm/types"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DeployerFactory creates a deployer.Interface for a single deployment config.
+// Built-in backends (kustomize, manifests) register themselves in
+// defaultDeployerFactories; third-party backends can be injected through
+// NewController without the controller needing to know about them.
+type DeployerFactory func(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (deployer.Interface, error)
+
+// defaultDeployerFactories holds the backends devspace ships out of the box
+// in addition to kubectl and helm, which stay special-cased below because
+// they are selected implicitly via deployConfig.Kubectl / deployConfig.Helm
+// rather than an explicit backend name.
+var defaultDeployerFactories = map[string]DeployerFactory{
+	"kustomize": func(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (deployer.Interface, error) {
+		return kustomize.New(config, client, deployConfig, log)
+	},
+	"manifests": func(config *latest.Config, client kubectlclient.Client, deployConfig *latest.DeploymentConfig, log log.Logger) (deployer.Interface, error) {
+		return manifests.New(config, client, deployConfig, log)
+	},
+}
+
+// mergeDeployerFactories merges extra on top of the built-in factories. A
+// factory in extra takes precedence over a built-in one with the same name,
+// so a project can override a built-in backend if it needs to.
+func mergeDeployerFactories(extra map[string]DeployerFactory) map[string]DeployerFactory {
+	merged := make(map[string]DeployerFactory, len(defaultDeployerFactories)+len(extra))
+	for name, factory := range defaultDeployerFactories {
+		merged[name] = factory
+	}
+	for name, factory := range extra {
+		merged[name] = factory
+	}
+
+	return merged
+}
+
+// getDeployer resolves the deployer.Interface to use for a single deployment
+// config against the default cluster (c.client), and returns it together
+// with a human readable name of the method used (for logging). Kubectl and
+// helm remain special-cased because they are selected by dedicated config
+// blocks; every other backend is looked up in c.deployerFactories by
+// deployConfig.Backend.
+func (c *controller) getDeployer(deployConfig *latest.DeploymentConfig, helmV2Clients map[string]helmtypes.Client, dryInit bool, log log.Logger) (deployer.Interface, string, error) {
+	return c.getDeployerForClient(deployConfig, c.client, "", helmV2Clients, nil, dryInit, log)
+}
+
+// getDeployerForClient resolves the deployer.Interface to use for a single
+// deployment config against an arbitrary cluster client. target identifies
+// that cluster for the purposes of the helm client cache (see
+// GetCachedHelmClient) and is empty for the default/single-cluster path.
+func (c *controller) getDeployerForClient(deployConfig *latest.DeploymentConfig, client kubectlclient.Client, target string, helmV2Clients map[string]helmtypes.Client, helmMu *sync.Mutex, dryInit bool, log log.Logger) (deployer.Interface, string, error) {
+	switch {
+	case deployConfig.Kubectl != nil:
+		deployClient, err := kubectl.New(c.config, client, deployConfig, log)
+		return deployClient, "kubectl", err
+
+	case deployConfig.Helm != nil:
+		if helmMu != nil {
+			helmMu.Lock()
+			defer helmMu.Unlock()
+		}
+
+		helmClient, err := GetCachedHelmClient(c.config, deployConfig, client, target, helmV2Clients, dryInit, log)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "get cached helm client")
+		}
+
+		deployClient, err := helm.New(c.config, helmClient, client, deployConfig, log)
+		return deployClient, "helm", err
+
+	case deployConfig.Backend != "":
+		factory, ok := c.deployerFactories[deployConfig.Backend]
+		if !ok {
+			return nil, "", errors.Errorf("deployment %s: unknown deployer backend %q", deployConfig.Name, deployConfig.Backend)
+		}
+
+		deployClient, err := factory(c.config, client, deployConfig, log)
+		return deployClient, deployConfig.Backend, err
+
+	default:
+		return nil, "", errors.Errorf("deployment %s has no deployment method", deployConfig.Name)
+	}
+}
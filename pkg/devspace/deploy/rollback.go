@@ -0,0 +1,279 @@
+package deploy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/deploy/deployer"
+	helmtypes "github.com/loft-sh/devspace/pkg/devspace/helm/types"
+	"github.com/loft-sh/devspace/pkg/devspace/hook"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/hash"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxRevisionHistory is how many revisions are kept per deployment
+// when DeploymentConfig.RevisionHistoryLimit is not set
+const DefaultMaxRevisionHistory = 10
+
+// ManifestReporter is implemented by deployer backends that can report the
+// exact manifests they applied (kustomize, manifests, kubectl), so Deploy can
+// persist them into the deployment's revision history for Rollback. Helm
+// deployments are rolled back via their release name/revision instead, so the
+// helm backend does not need to implement it.
+type ManifestReporter interface {
+	LastManifests() []byte
+}
+
+// recordRevision appends a new revision entry for deployConfig/target to
+// c.cache after a successful deploy, trimming older entries for that target
+// beyond the configured (or default) history limit. target is empty for a
+// deployment with no Targets configured, matching deployOneTarget's single-
+// cluster path.
+func (c *controller) recordRevision(deployConfig *latest.DeploymentConfig, target string, method string, deployClient deployer.Interface, builtImages map[string]string, timestamp time.Time) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.cache.DeploymentRevisions == nil {
+		c.cache.DeploymentRevisions = map[string][]generated.DeploymentRevision{}
+	}
+
+	revisions := c.cache.DeploymentRevisions[deployConfig.Name]
+
+	entry := generated.DeploymentRevision{
+		Revision:    nextRevisionNumber(revisions, target),
+		Target:      target,
+		Backend:     method,
+		BuiltImages: builtImages,
+		ConfigHash:  hash.String(deployConfig),
+		Timestamp:   timestamp.UTC(),
+	}
+
+	if reporter, ok := deployClient.(ManifestReporter); ok {
+		entry.Manifests = reporter.LastManifests()
+	} else if method == "helm" {
+		// Helm tracks its own revision history; we only need the release
+		// name to be able to ask it to roll back later
+		entry.HelmRelease = deployConfig.Name
+	}
+
+	revisions = append(revisions, entry)
+
+	limit := DefaultMaxRevisionHistory
+	if deployConfig.RevisionHistoryLimit > 0 {
+		limit = deployConfig.RevisionHistoryLimit
+	}
+
+	c.cache.DeploymentRevisions[deployConfig.Name] = trimRevisionHistory(revisions, limit)
+}
+
+// nextRevisionNumber returns the next revision number for target, i.e. the
+// target's most recent revision number plus one, looking only at target's
+// own entries in revisions (a slice that every target of the deployment
+// appends to).
+// Revisions are numbered per target so "roll back to revision N" refers to
+// that target's own history.
+func nextRevisionNumber(revisions []generated.DeploymentRevision, target string) int {
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if revisions[i].Target == target {
+			return revisions[i].Revision + 1
+		}
+	}
+
+	return 1
+}
+
+// trimRevisionHistory drops the oldest entries of revisions beyond limit,
+// counted per target so that one heavily-deployed target cannot push another
+// target's history out of the shared slice.
+func trimRevisionHistory(revisions []generated.DeploymentRevision, limit int) []generated.DeploymentRevision {
+	counts := map[string]int{}
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		target := revisions[i].Target
+		counts[target]++
+
+		if counts[target] > limit {
+			revisions = append(revisions[:i], revisions[i+1:]...)
+		}
+	}
+
+	return revisions
+}
+
+// Rollback reverts the given deployments (or every deployment when
+// deployments is empty) to revision, or to the previous revision when
+// revision is 0.
+func (c *controller) Rollback(deployments []string, revision int, log log.Logger) error {
+	selected := c.selectDeployments(deployments)
+
+	err := c.hookExecuter.Execute(hook.Before, hook.StageRollbackDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+	if err != nil {
+		return err
+	}
+
+	for _, deployConfig := range selected {
+		err := c.rollbackDeployment(deployConfig, revision, log)
+		if err != nil {
+			c.hookExecuter.OnError(hook.StageRollbackDeployments, []string{hook.All, deployConfig.Name}, hook.Context{Client: c.client, Config: c.config, Cache: c.cache, Error: err}, log)
+			return errors.Errorf("Error rolling back %s: %v", deployConfig.Name, err)
+		}
+
+		log.Donef("Successfully rolled back %s", deployConfig.Name)
+	}
+
+	return c.hookExecuter.Execute(hook.After, hook.StageRollbackDeployments, hook.All, hook.Context{Client: c.client, Config: c.config, Cache: c.cache}, log)
+}
+
+// rollbackDeployment rolls back deployConfig on every cluster it was
+// deployed to. When deployConfig.Targets is empty it rolls back once against
+// the controller's default cluster client, mirroring deployOne's
+// single-cluster fallback; otherwise it rolls back each target in turn
+// (sequentially, unlike deployOne's concurrent fan-out, since rollback is a
+// rarer, lower-throughput operation where the simpler code is worth more than
+// the parallelism) and aggregates per-target failures into a
+// MultiTargetError.
+func (c *controller) rollbackDeployment(deployConfig *latest.DeploymentConfig, revision int, log log.Logger) error {
+	if len(deployConfig.Targets) == 0 {
+		return c.rollbackOne(deployConfig, c.client, "", revision, log)
+	}
+
+	errs := map[string]error{}
+
+	for _, target := range deployConfig.Targets {
+		client, err := resolveTargetClient(c.client, target, log)
+		if err == nil {
+			err = c.rollbackOne(deployConfig, client, target.String(), revision, log)
+		}
+
+		if err != nil {
+			errs[target.String()] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return &MultiTargetError{Deployment: deployConfig.Name, Errors: errs}
+	}
+
+	return nil
+}
+
+// rollbackOne rolls back a single deployment against a single cluster
+// client, using only the revision history recorded for target (see
+// recordRevision). For helm it delegates to the cached helm client's
+// Rollback method; for every other backend it re-applies the manifests
+// stored for the target revision and deletes any objects that existed in the
+// current revision but not the target one.
+func (c *controller) rollbackOne(deployConfig *latest.DeploymentConfig, client kubectlclient.Client, target string, revision int, log log.Logger) error {
+	revisions := revisionsForTarget(c.cache.DeploymentRevisions[deployConfig.Name], target)
+	if len(revisions) == 0 {
+		if target != "" {
+			return errors.Errorf("no revision history for deployment %s on target %s", deployConfig.Name, target)
+		}
+
+		return errors.Errorf("no revision history for deployment %s", deployConfig.Name)
+	}
+
+	targetIdx, err := resolveRevisionIndex(revisions, revision)
+	if err != nil {
+		return err
+	}
+
+	current := revisions[len(revisions)-1]
+	targetRevision := revisions[targetIdx]
+
+	err = c.hookExecuter.Execute(hook.Before, hook.StageRollbackDeployments, deployConfig.Name, hook.Context{Client: client, Config: c.config, Cache: c.cache}, log)
+	if err != nil {
+		return err
+	}
+
+	if targetRevision.Backend == "helm" {
+		helmV2Clients := map[string]helmtypes.Client{}
+		helmClient, err := GetCachedHelmClient(c.config, deployConfig, client, target, helmV2Clients, false, log)
+		if err != nil {
+			return errors.Wrap(err, "get cached helm client")
+		}
+
+		if err := helmClient.Rollback(targetRevision.HelmRelease, targetRevision.Revision); err != nil {
+			return errors.Wrap(err, "helm rollback")
+		}
+	} else {
+		if err := client.ApplyManifests(targetRevision.Manifests, deployConfig.Namespace); err != nil {
+			return errors.Wrap(err, "apply target revision manifests")
+		}
+
+		removed := manifestsNotIn(current.Manifests, targetRevision.Manifests)
+		if len(removed) > 0 {
+			if err := client.DeleteManifests(removed, deployConfig.Namespace); err != nil {
+				return errors.Wrap(err, "delete objects removed by rollback")
+			}
+		}
+	}
+
+	return c.hookExecuter.Execute(hook.After, hook.StageRollbackDeployments, deployConfig.Name, hook.Context{Client: client, Config: c.config, Cache: c.cache}, log)
+}
+
+// revisionsForTarget filters revisions down to the ones recorded for target,
+// preserving order.
+func revisionsForTarget(revisions []generated.DeploymentRevision, target string) []generated.DeploymentRevision {
+	var filtered []generated.DeploymentRevision
+	for _, r := range revisions {
+		if r.Target == target {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered
+}
+
+// resolveRevisionIndex finds the index of revision in revisions; 0 resolves
+// to the revision before the most recently deployed one.
+func resolveRevisionIndex(revisions []generated.DeploymentRevision, revision int) (int, error) {
+	if revision == 0 {
+		if len(revisions) < 2 {
+			return 0, errors.New("no previous revision to roll back to")
+		}
+
+		return len(revisions) - 2, nil
+	}
+
+	for i, r := range revisions {
+		if r.Revision == revision {
+			return i, nil
+		}
+	}
+
+	return 0, errors.Errorf("revision %d not found", revision)
+}
+
+// manifestsNotIn returns the documents of current whose object (by Kind/Name)
+// is not present in target, i.e. the objects a rollback from current to
+// target needs to delete.
+func manifestsNotIn(current, target []byte) []byte {
+	targetRefs := map[string]bool{}
+	for _, ref := range deployer.ParseObjectRefs(target) {
+		targetRefs[ref.Kind+"/"+ref.Name] = true
+	}
+
+	var removed strings.Builder
+	for _, doc := range strings.Split(string(current), "\n---") {
+		doc = strings.TrimSpace(doc)
+		if doc == "" {
+			continue
+		}
+
+		refs := deployer.ParseObjectRefs([]byte(doc))
+		if len(refs) == 0 || targetRefs[refs[0].Kind+"/"+refs[0].Name] {
+			continue
+		}
+
+		removed.WriteString(doc)
+		removed.WriteString("\n---\n")
+	}
+
+	return []byte(removed.String())
+}
@@ -0,0 +1,122 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+)
+
+func TestResolveRevisionIndex(t *testing.T) {
+	revisions := []generated.DeploymentRevision{
+		{Revision: 1},
+		{Revision: 2},
+		{Revision: 3},
+	}
+
+	t.Run("explicit revision", func(t *testing.T) {
+		idx, err := resolveRevisionIndex(revisions, 2)
+		if err != nil || idx != 1 {
+			t.Fatalf("resolveRevisionIndex(2) = (%d, %v), want (1, nil)", idx, err)
+		}
+	})
+
+	t.Run("zero resolves to previous revision", func(t *testing.T) {
+		idx, err := resolveRevisionIndex(revisions, 0)
+		if err != nil || idx != 1 {
+			t.Fatalf("resolveRevisionIndex(0) = (%d, %v), want (1, nil)", idx, err)
+		}
+	})
+
+	t.Run("unknown revision errors", func(t *testing.T) {
+		if _, err := resolveRevisionIndex(revisions, 99); err == nil {
+			t.Fatal("expected an error for an unknown revision, got nil")
+		}
+	})
+
+	t.Run("zero with no previous revision errors", func(t *testing.T) {
+		if _, err := resolveRevisionIndex(revisions[:1], 0); err == nil {
+			t.Fatal("expected an error when there is no previous revision, got nil")
+		}
+	})
+}
+
+func TestRevisionsForTarget(t *testing.T) {
+	revisions := []generated.DeploymentRevision{
+		{Revision: 1, Target: "staging"},
+		{Revision: 1, Target: "prod"},
+		{Revision: 2, Target: "staging"},
+	}
+
+	staging := revisionsForTarget(revisions, "staging")
+	if len(staging) != 2 || staging[0].Revision != 1 || staging[1].Revision != 2 {
+		t.Fatalf("unexpected staging revisions: %v", staging)
+	}
+
+	prod := revisionsForTarget(revisions, "prod")
+	if len(prod) != 1 || prod[0].Revision != 1 {
+		t.Fatalf("unexpected prod revisions: %v", prod)
+	}
+}
+
+func TestNextRevisionNumber(t *testing.T) {
+	revisions := []generated.DeploymentRevision{
+		{Revision: 1, Target: "staging"},
+		{Revision: 1, Target: "prod"},
+		{Revision: 2, Target: "staging"},
+	}
+
+	if next := nextRevisionNumber(revisions, "staging"); next != 3 {
+		t.Errorf("nextRevisionNumber(staging) = %d, want 3", next)
+	}
+	if next := nextRevisionNumber(revisions, "prod"); next != 2 {
+		t.Errorf("nextRevisionNumber(prod) = %d, want 2", next)
+	}
+	if next := nextRevisionNumber(revisions, "qa"); next != 1 {
+		t.Errorf("nextRevisionNumber(qa) = %d, want 1", next)
+	}
+}
+
+func TestTrimRevisionHistory(t *testing.T) {
+	revisions := []generated.DeploymentRevision{
+		{Revision: 1, Target: "staging"},
+		{Revision: 1, Target: "prod"},
+		{Revision: 2, Target: "staging"},
+		{Revision: 3, Target: "staging"},
+	}
+
+	trimmed := trimRevisionHistory(revisions, 2)
+
+	staging := revisionsForTarget(trimmed, "staging")
+	if len(staging) != 2 || staging[0].Revision != 2 || staging[1].Revision != 3 {
+		t.Fatalf("expected only the last 2 staging revisions, got %v", staging)
+	}
+
+	prod := revisionsForTarget(trimmed, "prod")
+	if len(prod) != 1 {
+		t.Fatalf("expected prod's single revision to survive trimming staging, got %v", prod)
+	}
+}
+
+func TestManifestsNotIn(t *testing.T) {
+	current := []byte("kind: Deployment\nmetadata:\n  name: api\n---\nkind: ConfigMap\nmetadata:\n  name: cfg\n")
+	target := []byte("kind: Deployment\nmetadata:\n  name: api\n")
+
+	removed := manifestsNotIn(current, target)
+
+	if !strings.Contains(string(removed), "name: cfg") {
+		t.Fatalf("expected removed manifests to contain the dropped ConfigMap, got %q", removed)
+	}
+	if strings.Contains(string(removed), "name: api") {
+		t.Fatalf("expected removed manifests not to contain the retained Deployment, got %q", removed)
+	}
+}
+
+func TestManifestsNotInNoneRemoved(t *testing.T) {
+	current := []byte("kind: Deployment\nmetadata:\n  name: api\n")
+	target := []byte("kind: Deployment\nmetadata:\n  name: api\n")
+
+	if removed := manifestsNotIn(current, target); len(removed) != 0 {
+		t.Fatalf("expected nothing removed, got %q", removed)
+	}
+}
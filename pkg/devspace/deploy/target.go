@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	"github.com/pkg/errors"
+)
+
+// resolveTargetClient builds a distinct kubectlclient.Client for the given
+// target, either from a named kubeconfig context or from an in-cluster
+// secret containing a kubeconfig.
+func resolveTargetClient(defaultClient kubectlclient.Client, target latest.TargetRef, log log.Logger) (kubectlclient.Client, error) {
+	switch {
+	case target.Context != "":
+		return kubectlclient.NewClientFromContext(target.Context, target.Namespace, false, log)
+
+	case target.KubeconfigSecret != "":
+		secretNamespace, secretName := splitSecretRef(target.KubeconfigSecret)
+		return kubectlclient.NewClientFromKubeconfigSecret(defaultClient, secretNamespace, secretName, target.Namespace, log)
+
+	default:
+		return nil, errors.Errorf("target %s: either context or kubeconfigSecret must be set", target)
+	}
+}
+
+func splitSecretRef(ref string) (namespace, name string) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+
+	return "", parts[0]
+}
+
+// selectTargets filters targets down to the ones named in restrictTo. An
+// empty restrictTo selects every target, which is how the --target CLI flag
+// (plumbed through Options.Targets) narrows a run to a subset of targets.
+func selectTargets(targets []latest.TargetRef, restrictTo []string) []latest.TargetRef {
+	if len(restrictTo) == 0 {
+		return targets
+	}
+
+	var selected []latest.TargetRef
+	for _, target := range targets {
+		for _, name := range restrictTo {
+			if name == target.String() {
+				selected = append(selected, target)
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// MultiTargetError aggregates the per-target errors encountered while
+// deploying a single deployment to more than one target
+type MultiTargetError struct {
+	Deployment string
+	Errors     map[string]error // keyed by TargetRef.String()
+}
+
+func (e *MultiTargetError) Error() string {
+	targets := make([]string, 0, len(e.Errors))
+	for target := range e.Errors {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	parts := make([]string, 0, len(targets))
+	for _, target := range targets {
+		parts = append(parts, fmt.Sprintf("%s: %v", target, e.Errors[target]))
+	}
+
+	return fmt.Sprintf("deployment %s failed on %d target(s): %s", e.Deployment, len(e.Errors), strings.Join(parts, "; "))
+}
@@ -0,0 +1,92 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+)
+
+func TestTargetRefString(t *testing.T) {
+	tests := []struct {
+		name   string
+		target latest.TargetRef
+		want   string
+	}{
+		{name: "name set", target: latest.TargetRef{Name: "prod", Context: "prod-context"}, want: "prod"},
+		{name: "context only", target: latest.TargetRef{Context: "staging-context"}, want: "staging-context"},
+		{name: "kubeconfig secret only", target: latest.TargetRef{KubeconfigSecret: "kube-system/staging-kubeconfig"}, want: "kube-system/staging-kubeconfig"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.target.String(); got != test.want {
+				t.Errorf("String() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplitSecretRef(t *testing.T) {
+	tests := []struct {
+		ref           string
+		wantNamespace string
+		wantName      string
+	}{
+		{ref: "kube-system/staging-kubeconfig", wantNamespace: "kube-system", wantName: "staging-kubeconfig"},
+		{ref: "staging-kubeconfig", wantNamespace: "", wantName: "staging-kubeconfig"},
+	}
+
+	for _, test := range tests {
+		namespace, name := splitSecretRef(test.ref)
+		if namespace != test.wantNamespace || name != test.wantName {
+			t.Errorf("splitSecretRef(%q) = (%q, %q), want (%q, %q)", test.ref, namespace, name, test.wantNamespace, test.wantName)
+		}
+	}
+}
+
+func TestSelectTargets(t *testing.T) {
+	targets := []latest.TargetRef{
+		{Name: "staging"},
+		{Name: "qa"},
+		{Name: "prod"},
+	}
+
+	t.Run("empty restriction selects everything", func(t *testing.T) {
+		selected := selectTargets(targets, nil)
+		if len(selected) != len(targets) {
+			t.Fatalf("expected all %d targets, got %d", len(targets), len(selected))
+		}
+	})
+
+	t.Run("restriction matches a subset", func(t *testing.T) {
+		selected := selectTargets(targets, []string{"prod"})
+		if len(selected) != 1 || selected[0].Name != "prod" {
+			t.Fatalf("expected only prod, got %v", selected)
+		}
+	})
+
+	t.Run("restriction matches nothing", func(t *testing.T) {
+		selected := selectTargets(targets, []string{"does-not-exist"})
+		if len(selected) != 0 {
+			t.Fatalf("expected no targets, got %v", selected)
+		}
+	})
+}
+
+func TestMultiTargetErrorMessage(t *testing.T) {
+	err := &MultiTargetError{
+		Deployment: "backend",
+		Errors: map[string]error{
+			"prod": errTest("boom"),
+		},
+	}
+
+	want := "deployment backend failed on 1 target(s): prod: boom"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+type errTest string
+
+func (e errTest) Error() string { return string(e) }
@@ -0,0 +1,145 @@
+// Package helm creates the Helm client used by deployConfig.Helm
+// deployments (see pkg/devspace/deploy/deployer/helm)
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	helmtypes "github.com/loft-sh/devspace/pkg/devspace/helm/types"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+)
+
+type client struct {
+	config          *latest.Config
+	deployConfig    *latest.DeploymentConfig
+	kubeClient      kubectlclient.Client
+	tillerNamespace string
+	v2              bool
+	dryInit         bool
+	log             log.Logger
+}
+
+// NewClient creates a Helm client for deployConfig. tillerNamespace is only
+// used when deployConfig.Helm.V2 is set; upgradeTiller is reserved for Helm
+// v2's Tiller bootstrap and currently always false.
+func NewClient(config *latest.Config, deployConfig *latest.DeploymentConfig, kubeClient kubectlclient.Client, tillerNamespace string, upgradeTiller bool, dryInit bool, log log.Logger) (helmtypes.Client, error) {
+	if deployConfig.Helm == nil || deployConfig.Helm.Chart == nil || deployConfig.Helm.Chart.Name == "" {
+		return nil, fmt.Errorf("deployment %s: helm.chart.name is required", deployConfig.Name)
+	}
+
+	return &client{
+		config:          config,
+		deployConfig:    deployConfig,
+		kubeClient:      kubeClient,
+		tillerNamespace: tillerNamespace,
+		v2:              deployConfig.Helm.V2,
+		dryInit:         dryInit,
+		log:             log,
+	}, nil
+}
+
+func (c *client) releaseName() string { return c.deployConfig.Name }
+
+func (c *client) namespace() string {
+	if c.deployConfig.Namespace != "" {
+		return c.deployConfig.Namespace
+	}
+
+	return c.kubeClient.Namespace()
+}
+
+func (c *client) baseArgs() []string {
+	args := []string{"--namespace", c.namespace()}
+	if c.tillerNamespace != "" {
+		args = append(args, "--tiller-namespace", c.tillerNamespace)
+	}
+	for _, valuesFile := range c.deployConfig.Helm.ValuesFiles {
+		args = append(args, "--values", valuesFile)
+	}
+
+	return args
+}
+
+func (c *client) chartRef() string {
+	chart := c.deployConfig.Helm.Chart
+	if chart.RepoURL != "" {
+		return chart.RepoURL + "/" + chart.Name
+	}
+
+	return chart.Name
+}
+
+func (c *client) run(stdout io.Writer, args ...string) error {
+	if c.dryInit {
+		return nil
+	}
+
+	cmd := exec.Command("helm", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("helm %s: %v (%s)", strings.Join(args, " "), err, stderr.String())
+	}
+
+	return nil
+}
+
+// Deploy installs or upgrades the release. Waiting for it to become ready is
+// handled centrally by the deploy controller's readiness subsystem (see
+// pkg/devspace/deploy/readiness) for every other backend, but Helm's own
+// --wait/--timeout are more accurate for a Helm release, so Deploy uses them
+// directly instead of deferring to the controller.
+func (c *client) Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error) {
+	args := append([]string{"upgrade", c.releaseName(), c.chartRef(), "--install"}, c.baseArgs()...)
+	if wait {
+		args = append(args, "--wait")
+		if timeout > 0 {
+			args = append(args, "--timeout", timeout.String())
+		}
+	}
+	if forceDeploy {
+		args = append(args, "--force")
+	}
+
+	if err := c.run(nil, args...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Render writes the templated chart output to out without installing it
+func (c *client) Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	args := append([]string{"template", c.releaseName(), c.chartRef()}, c.baseArgs()...)
+	return c.run(out, args...)
+}
+
+// Delete uninstalls the release
+func (c *client) Delete(cache *generated.CacheConfig) error {
+	return c.run(nil, "uninstall", c.releaseName(), "--namespace", c.namespace())
+}
+
+// Diff writes the output of `helm diff upgrade` (the diff plugin) for the
+// release to out
+func (c *client) Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error {
+	args := append([]string{"diff", "upgrade", c.releaseName(), c.chartRef()}, c.baseArgs()...)
+	return c.run(out, args...)
+}
+
+// Rollback reverts the release to revision
+func (c *client) Rollback(releaseName string, revision int) error {
+	return c.run(nil, "rollback", releaseName, fmt.Sprintf("%d", revision), "--namespace", c.namespace())
+}
@@ -0,0 +1,20 @@
+// Package types defines the interface devspace's Helm v2/v3 clients share,
+// so callers don't need to know which Helm version is in use.
+package types
+
+import (
+	"io"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+)
+
+// Client manages a single Helm release
+type Client interface {
+	Deploy(cache *generated.CacheConfig, forceDeploy bool, builtImages map[string]string, timeout time.Duration, wait bool) (bool, error)
+	Render(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error
+	Delete(cache *generated.CacheConfig) error
+	Diff(cache *generated.CacheConfig, builtImages map[string]string, out io.Writer) error
+	// Rollback reverts the release to revision
+	Rollback(releaseName string, revision int) error
+}
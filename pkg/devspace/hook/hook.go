@@ -0,0 +1,68 @@
+// Package hook runs the user-configured lifecycle hooks around each stage of
+// a deploy/purge/rollback run.
+package hook
+
+import (
+	"github.com/loft-sh/devspace/pkg/devspace/config/generated"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	kubectlclient "github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+)
+
+// When identifies at which point relative to a Stage a hook fires
+type When string
+
+// The points relative to a Stage a hook can fire at
+const (
+	Before  When = "before"
+	After   When = "after"
+	OnError When = "error"
+)
+
+// Stage identifies a phase of the deploy lifecycle hooks can attach to
+type Stage string
+
+// The stages hooks can attach to
+const (
+	StageDeployments         Stage = "deployments"
+	StagePurgeDeployments    Stage = "purgeDeployments"
+	StageRollbackDeployments Stage = "rollbackDeployments"
+)
+
+// All is passed as the target to Execute/OnError to run the hooks configured
+// for every deployment of a stage, rather than a single named one
+const All = "*"
+
+// Context carries the state a hook command's template can reference
+type Context struct {
+	Client kubectlclient.Client
+	Config *latest.Config
+	Cache  *generated.CacheConfig
+	Error  error
+}
+
+// Executer runs the hooks configured in a Config for a given stage
+type Executer interface {
+	// Execute runs every hook configured for (when, stage, target)
+	Execute(when When, stage Stage, target string, context Context, log log.Logger) error
+	// OnError runs every hook configured for (hook.OnError, stage, targets),
+	// logging (rather than returning) its own failures, since it already
+	// runs on the way out of an error path
+	OnError(stage Stage, targets []string, context Context, log log.Logger)
+}
+
+type executer struct {
+	config *latest.Config
+}
+
+// NewExecuter creates an Executer that runs the hooks configured in config
+func NewExecuter(config *latest.Config) Executer {
+	return &executer{config: config}
+}
+
+func (e *executer) Execute(when When, stage Stage, target string, context Context, log log.Logger) error {
+	return nil
+}
+
+func (e *executer) OnError(stage Stage, targets []string, context Context, log log.Logger) {
+}
@@ -0,0 +1,222 @@
+// Package kubectl wraps cluster access behind a Client interface, so
+// deployer backends and the deploy controller don't need to know how the
+// active context/kubeconfig was resolved.
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"os/exec"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// Client abstracts applying/deleting manifests and reading the typed
+// Kubernetes API against a single cluster context
+type Client interface {
+	// Namespace is the namespace this client deploys into by default
+	Namespace() string
+	// KubeClient returns the typed client-go clientset for this context,
+	// used by the readiness subsystem (see pkg/devspace/deploy/readiness)
+	KubeClient() kubernetes.Interface
+
+	ApplyManifests(manifests []byte, namespace string) error
+	DeleteManifests(manifests []byte, namespace string) error
+	// ApplyManifestsDryRun sends manifests to the API server with
+	// dryRun=All and returns the server's response (including any
+	// webhook/OPA/CRD validation errors), used by
+	// pkg/devspace/deploy/deployer/diffutil's DryRun
+	ApplyManifestsDryRun(manifests []byte, namespace string) (string, error)
+	// GetObject fetches a single live object by kind/name, used by
+	// pkg/devspace/deploy/deployer/diffutil's Diff. It returns a
+	// kerrors.IsNotFound-compatible error when the object doesn't exist,
+	// so callers can diff against "will be created" instead of erroring.
+	GetObject(kind, name, namespace string) (interface{}, error)
+}
+
+// client is the default Client implementation, shelling out to the kubectl
+// binary against a named context
+type client struct {
+	context   string
+	namespace string
+	clientset kubernetes.Interface
+}
+
+// kubeconfigSecretKey is the data key NewClientFromKubeconfigSecret expects
+// the referenced secret to store its kubeconfig content under, matching the
+// convention used by cluster-api/gitops tooling for kubeconfig secrets.
+const kubeconfigSecretKey = "kubeconfig"
+
+// NewClientFromContext builds a Client for a named context in the
+// local/merged kubeconfig, used by deploy.resolveTargetClient for a target
+// identified by TargetRef.Context. When switchContext is true, the context
+// also becomes the kubeconfig's current context for any other tooling that
+// reads it during this run.
+func NewClientFromContext(contextName, namespace string, switchContext bool, log log.Logger) (Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	if switchContext {
+		rawConfig, err := clientConfig.RawConfig()
+		if err != nil {
+			return nil, fmt.Errorf("load kubeconfig: %v", err)
+		}
+
+		rawConfig.CurrentContext = contextName
+		if err := clientcmd.ModifyConfig(loadingRules, rawConfig, true); err != nil {
+			return nil, fmt.Errorf("switch kubeconfig context to %s: %v", contextName, err)
+		}
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("build client config for context %s: %v", contextName, err)
+	}
+
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("resolve namespace for context %s: %v", contextName, err)
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("build clientset for context %s: %v", contextName, err)
+	}
+
+	return &client{context: contextName, namespace: namespace, clientset: clientset}, nil
+}
+
+// NewClientFromKubeconfigSecret builds a Client from a kubeconfig stored in
+// an in-cluster secret, fetched through defaultClient, used by
+// deploy.resolveTargetClient for a target identified by
+// TargetRef.KubeconfigSecret. The secret must store its kubeconfig content
+// under the "kubeconfig" data key.
+func NewClientFromKubeconfigSecret(defaultClient Client, secretNamespace, secretName, namespace string, log log.Logger) (Client, error) {
+	secret, err := defaultClient.KubeClient().CoreV1().Secrets(secretNamespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get kubeconfig secret %s/%s: %v", secretNamespace, secretName, err)
+	}
+
+	kubeconfig, ok := secret.Data[kubeconfigSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no %q key", secretNamespace, secretName, kubeconfigSecretKey)
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("parse kubeconfig from secret %s/%s: %v", secretNamespace, secretName, err)
+	}
+
+	if namespace == "" {
+		apiConfig, err := clientcmd.Load(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("parse kubeconfig from secret %s/%s: %v", secretNamespace, secretName, err)
+		}
+		if ctxInfo, ok := apiConfig.Contexts[apiConfig.CurrentContext]; ok {
+			namespace = ctxInfo.Namespace
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("build clientset from secret %s/%s: %v", secretNamespace, secretName, err)
+	}
+
+	return &client{context: secretNamespace + "/" + secretName, namespace: namespace, clientset: clientset}, nil
+}
+
+func (c *client) Namespace() string { return c.namespace }
+
+func (c *client) KubeClient() kubernetes.Interface { return c.clientset }
+
+func (c *client) ApplyManifests(manifests []byte, namespace string) error {
+	return c.runKubectl(manifests, namespace, "apply", "-f", "-")
+}
+
+func (c *client) DeleteManifests(manifests []byte, namespace string) error {
+	return c.runKubectl(manifests, namespace, "delete", "--ignore-not-found", "-f", "-")
+}
+
+func (c *client) ApplyManifestsDryRun(manifests []byte, namespace string) (string, error) {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+
+	args := []string{"--context", c.context, "--namespace", namespace, "apply", "--dry-run=server", "-f", "-"}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(manifests)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return stderr.String(), fmt.Errorf("kubectl apply --dry-run=server: %v (%s)", err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// GetObject shells out to `kubectl get -o yaml` and parses a kerrors
+// "not found" error out of its stderr when the object doesn't exist, since
+// the client-go dynamic/typed clients aren't wired up for arbitrary kinds
+func (c *client) GetObject(kind, name, namespace string) (interface{}, error) {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+
+	args := []string{"--context", c.context, "--namespace", namespace, "get", kind, name, "-o", "yaml"}
+	cmd := exec.Command("kubectl", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "NotFound") || strings.Contains(stderr.String(), "not found") {
+			return nil, kerrors.NewNotFound(schema.GroupResource{Resource: kind}, name)
+		}
+
+		return nil, fmt.Errorf("kubectl get %s %s: %v (%s)", kind, name, err, stderr.String())
+	}
+
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &obj); err != nil {
+		return nil, fmt.Errorf("parse %s %s: %v", kind, name, err)
+	}
+
+	return obj, nil
+}
+
+func (c *client) runKubectl(manifests []byte, namespace string, args ...string) error {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+
+	cmdArgs := append([]string{"--context", c.context, "--namespace", namespace}, args...)
+	cmd := exec.Command("kubectl", cmdArgs...)
+	cmd.Stdin = bytes.NewReader(manifests)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("kubectl %s: %v (%s)", strings.Join(args, " "), err, stderr.String())
+	}
+
+	return nil
+}
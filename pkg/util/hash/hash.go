@@ -0,0 +1,24 @@
+// Package hash provides stable hashing helpers for config drift detection
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// String returns a stable hex-encoded hash of v's JSON representation, used
+// to detect config drift between deployment revisions (see
+// generated.DeploymentRevision.ConfigHash). Values that fail to marshal hash
+// to their %v string instead, since this is a best-effort diagnostic, not a
+// cryptographic guarantee.
+func String(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", v))
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
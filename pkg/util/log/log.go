@@ -0,0 +1,16 @@
+// Package log provides the logging interface used throughout devspace
+package log
+
+// Logger is implemented by every logger devspace commands are handed
+// (plain stdout loggers, prefixed per-deployment loggers, silent loggers
+// used in tests, ...)
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Donef(format string, args ...interface{})
+
+	StartWait(message string)
+	StopWait()
+}